@@ -0,0 +1,119 @@
+// Copyright (c) OpenFaaS Author(s) 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// templateStoreCacheDirName is the directory under the user's home
+// directory where cached copies of templates.json are stored.
+const templateStoreCacheDirName = "template-store-cache"
+
+// templateStoreCacheEntry is the on-disk representation of a cached
+// templates.json response, along with the validators needed to
+// conditionally re-fetch it.
+type templateStoreCacheEntry struct {
+	ETag         string         `json:"etag,omitempty"`
+	LastModified string         `json:"lastModified,omitempty"`
+	FetchedAt    time.Time      `json:"fetchedAt"`
+	Templates    []TemplateInfo `json:"templates"`
+
+	// RawBody is the exact templates.json bytes the above Templates were
+	// unmarshalled from. It is kept so that a cached entry served without
+	// re-contacting the store can still be checked against --checksum/
+	// --keyring whenever those are configured.
+	RawBody []byte `json:"rawBody,omitempty"`
+}
+
+// openfaasConfigDir returns ~/.openfaas, creating it if it does not already
+// exist. It is the root of all faas-cli state kept outside of a function's
+// source directory, such as the template store cache and stores.yaml.
+func openfaasConfigDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to determine home directory: %s", err)
+	}
+
+	dir := filepath.Join(home, ".openfaas")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("unable to create config directory %s: %s", dir, err)
+	}
+
+	return dir, nil
+}
+
+// templateStoreCacheDir returns ~/.openfaas/template-store-cache, creating
+// it if it does not already exist.
+func templateStoreCacheDir() (string, error) {
+	base, err := openfaasConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(base, templateStoreCacheDirName)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("unable to create template store cache directory %s: %s", dir, err)
+	}
+
+	return dir, nil
+}
+
+// templateStoreCacheFile returns the path of the cache file for the given
+// store URL, keyed by its SHA-256 hash so arbitrary URLs are safe file names.
+func templateStoreCacheFile(storeURL string) (string, error) {
+	dir, err := templateStoreCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(storeURL))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".json"), nil
+}
+
+// loadTemplateStoreCache reads the cached entry for storeURL, if any.
+func loadTemplateStoreCache(storeURL string) (*templateStoreCacheEntry, error) {
+	path, err := templateStoreCacheFile(storeURL)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("no cache found for %s: %s", storeURL, err)
+	}
+
+	entry := &templateStoreCacheEntry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil, fmt.Errorf("unable to parse cache for %s: %s", storeURL, err)
+	}
+
+	return entry, nil
+}
+
+// saveTemplateStoreCache persists entry as the cache for storeURL.
+func saveTemplateStoreCache(storeURL string, entry *templateStoreCacheEntry) error {
+	path, err := templateStoreCacheFile(storeURL)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("unable to marshal cache for %s: %s", storeURL, err)
+	}
+
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("unable to write cache for %s: %s", storeURL, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+// Copyright (c) OpenFaaS Author(s) 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// withTempHome points os.UserHomeDir at a fresh temporary directory for the
+// duration of a test, so template store cache/config files never touch the
+// real $HOME, and restores it afterwards.
+func withTempHome(t *testing.T) func() {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "faas-cli-home")
+	if err != nil {
+		t.Fatalf("unable to create temp home: %s", err)
+	}
+
+	oldHome := os.Getenv("HOME")
+	os.Setenv("HOME", dir)
+
+	return func() {
+		os.Setenv("HOME", oldHome)
+		os.RemoveAll(dir)
+	}
+}
+
+// resetTemplateStoreFlags restores the template-store package variables used
+// by list and the cache/verification paths to their flag defaults, so tests that exercise getTemplateInfo/fetchTemplateStores
+// don't leak flag state into one another.
+func resetTemplateStoreFlags() {
+	templateStoreURLs = []string{}
+	platform = allPlatforms
+	outputFormat = tableOutputFormat
+	refreshCache = false
+	offlineCache = false
+	cacheTTL = defaultCacheTTL
+	templateStoreChecksum = ""
+	templateStoreKeyring = ""
+	verbose = false
+}
+
+func TestGetTemplateInfoServesFromCacheWithinTTL(t *testing.T) {
+	defer withTempHome(t)()
+	resetTemplateStoreFlags()
+	defer resetTemplateStoreFlags()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`[{"template":"golang-http","platform":"x86_64"}]`))
+	}))
+	defer server.Close()
+
+	first, err := getTemplateInfo(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error on first fetch: %s", err)
+	}
+	if len(first) != 1 {
+		t.Fatalf("expected 1 template, got %d", len(first))
+	}
+
+	second, err := getTemplateInfo(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error on cached fetch: %s", err)
+	}
+	if len(second) != 1 {
+		t.Fatalf("expected 1 template from cache, got %d", len(second))
+	}
+
+	if requests != 1 {
+		t.Fatalf("expected exactly 1 HTTP request, cache should have served the second call, got %d requests", requests)
+	}
+}
+
+func TestGetTemplateInfoRefreshBypassesCache(t *testing.T) {
+	defer withTempHome(t)()
+	resetTemplateStoreFlags()
+	defer resetTemplateStoreFlags()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`[{"template":"golang-http","platform":"x86_64"}]`))
+	}))
+	defer server.Close()
+
+	if _, err := getTemplateInfo(server.URL); err != nil {
+		t.Fatalf("unexpected error on first fetch: %s", err)
+	}
+
+	refreshCache = true
+	if _, err := getTemplateInfo(server.URL); err != nil {
+		t.Fatalf("unexpected error on refreshed fetch: %s", err)
+	}
+
+	if requests != 2 {
+		t.Fatalf("expected --refresh to bypass the cache and issue a second request, got %d requests", requests)
+	}
+}
+
+func TestGetTemplateInfoOfflineRequiresCache(t *testing.T) {
+	defer withTempHome(t)()
+	resetTemplateStoreFlags()
+	defer resetTemplateStoreFlags()
+
+	offlineCache = true
+
+	if _, err := getTemplateInfo("https://example.invalid/templates.json"); err == nil {
+		t.Fatal("expected an error when --offline is set and nothing is cached")
+	}
+}
+
+func TestGetTemplateInfoConditionalGet304ReusesCache(t *testing.T) {
+	defer withTempHome(t)()
+	resetTemplateStoreFlags()
+	defer resetTemplateStoreFlags()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == "\"v1\"" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", "\"v1\"")
+		w.Write([]byte(`[{"template":"golang-http","platform":"x86_64"}]`))
+	}))
+	defer server.Close()
+
+	if _, err := getTemplateInfo(server.URL); err != nil {
+		t.Fatalf("unexpected error on first fetch: %s", err)
+	}
+
+	// Force revalidation instead of serving purely from the TTL-fresh cache.
+	cacheTTL = 0 * time.Second
+
+	second, err := getTemplateInfo(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error on conditional fetch: %s", err)
+	}
+	if len(second) != 1 {
+		t.Fatalf("expected the 304 response to fall back to the cached templates, got %d", len(second))
+	}
+
+	if requests != 2 {
+		t.Fatalf("expected a revalidation request once the TTL expired, got %d requests", requests)
+	}
+}
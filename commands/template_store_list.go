@@ -11,28 +11,53 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"strings"
+	"sync"
 	"text/tabwriter"
 	"time"
 
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
 )
 
 const (
 	// DefaultTemplatesStore is the URL where the official store can be found
 	DefaultTemplatesStore = "https://raw.githubusercontent.com/openfaas/store/master/templates.json"
 	allPlatforms          = "allPlatforms"
+
+	// templateStoreURLsEnvironment is a colon-separated list of store URLs,
+	// the plural counterpart of the legacy OPENFAAS_TEMPLATE_STORE_URL.
+	templateStoreURLsEnvironment = "OPENFAAS_TEMPLATE_STORES"
+
+	tableOutputFormat = "table"
+	jsonOutputFormat  = "json"
+	yamlOutputFormat  = "yaml"
+	wideOutputFormat  = "wide"
+
+	// defaultCacheTTL is how long a cached templates.json is considered
+	// fresh before getTemplateInfo revalidates it with the store.
+	defaultCacheTTL = 24 * time.Hour
 )
 
 var (
-	templateStoreURL   string
+	templateStoreURLs  []string
 	platform           string
+	outputFormat       string
+	refreshCache       bool
+	offlineCache       bool
+	cacheTTL           time.Duration
 	availablePlatforms = [...]string{"armhf", "x86_64", "arm64"}
+	availableOutputs   = [...]string{tableOutputFormat, jsonOutputFormat, yamlOutputFormat, wideOutputFormat}
 )
 
 func init() {
 	templateStoreListCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Shows additional language and platform")
-	templateStoreListCmd.PersistentFlags().StringVarP(&templateStoreURL, "url", "u", DefaultTemplatesStore, "Use as alternative store for templates")
+	templateStoreListCmd.PersistentFlags().StringArrayVarP(&templateStoreURLs, "url", "u", []string{}, "Use as alternative store for templates, can be repeated to query several stores")
 	templateStoreListCmd.Flags().StringVarP(&platform, "platform", "p", allPlatforms, "Shows the platform if the output is verbose")
+	templateStoreListCmd.Flags().StringVarP(&outputFormat, "output", "o", tableOutputFormat, "Output format, one of: table|json|yaml|wide")
+	templateStoreListCmd.PersistentFlags().BoolVar(&refreshCache, "refresh", false, "Force a re-fetch of the template store, bypassing the local cache")
+	templateStoreListCmd.PersistentFlags().BoolVar(&offlineCache, "offline", false, "Use the local template store cache only, failing if nothing is cached")
+	templateStoreListCmd.PersistentFlags().DurationVar(&cacheTTL, "cache-ttl", defaultCacheTTL, "Maximum age of the local template store cache before it is considered stale")
 
 	templateStoreCmd.AddCommand(templateStoreListCmd)
 }
@@ -42,32 +67,202 @@ var templateStoreListCmd = &cobra.Command{
 	Use:     `list`,
 	Short:   `List templates from OpenFaaS organizations`,
 	Aliases: []string{"ls"},
-	Long:    `List templates from official store or from custom URL or set the environmental variable OPENFAAS_TEMPLATE_STORE_URL to be the default store location`,
+	Long:    `List templates from official store or from custom URL(s), or set the environmental variable OPENFAAS_TEMPLATE_STORES to a colon-separated list of store locations. Additional stores can be configured permanently with "faas-cli template store add".`,
 	Example: `  faas-cli template store list
   faas-cli template store ls
   faas-cli template store ls --url=https://raw.githubusercontent.com/openfaas/store/master/templates.json
+  faas-cli template store ls --url=https://example.com/templates.json --url=https://example.com/other-templates.json
   faas-cli template store ls --verbose=true
-  faas-cli template store list --platform arm64`,
+  faas-cli template store list --platform arm64
+  faas-cli template store list --output json
+  faas-cli template store list --offline
+  faas-cli template store list --refresh --cache-ttl 1h
+  faas-cli template store list --checksum 9b98b6...d1
+  faas-cli template store list --keyring ~/.openfaas/store.pub`,
 	RunE: runTemplateStoreList,
 }
 
 func runTemplateStoreList(cmd *cobra.Command, args []string) error {
-	envTemplateRepoStore := os.Getenv(templateStoreURLEnvironment)
-	storeURL := getTemplateStoreURL(templateStoreURL, envTemplateRepoStore, DefaultTemplatesStore)
+	stores, storesErr := resolveTemplateStores(templateStoreURLs)
+	if storesErr != nil {
+		return fmt.Errorf("error while resolving template stores: %s", storesErr)
+	}
 
-	templatesInfo, templatesErr := getTemplateInfo(storeURL)
+	templatesInfo, templatesErr := fetchTemplateStores(stores)
 	if templatesErr != nil {
 		return fmt.Errorf("error while getting templates info: %s", templatesErr)
 	}
 
-	formattedOutput := formatTemplatesOutput(templatesInfo, verbose, platform)
+	if platform != allPlatforms {
+		if err := checkExistingPlatforms(platform); err != nil {
+			return err
+		}
+		templatesInfo = filterTemplatesByPlatform(templatesInfo, platform)
+	}
+
+	formattedOutput, err := formatTemplatesOutput(templatesInfo, verbose, outputFormat)
+	if err != nil {
+		return err
+	}
 
 	fmt.Fprintf(cmd.OutOrStdout(), "%s", formattedOutput)
 
 	return nil
 }
 
+// resolveTemplateStores builds the list of stores to query from every
+// source - repeated --url flags, the OPENFAAS_TEMPLATE_STORES colon-separated
+// environment variable, the legacy single-value OPENFAAS_TEMPLATE_STORE_URL
+// variable, and ~/.openfaas/stores.yaml managed by "faas-cli template store
+// add/remove" - then sorts the combined list by declared Priority (lowest
+// first) so collisions are resolved consistently regardless of which source
+// a store came from. When none are configured, it falls back to the official
+// DefaultTemplatesStore.
+func resolveTemplateStores(cliURLs []string) ([]templateStore, error) {
+	stores := []templateStore{}
+
+	for i, url := range cliURLs {
+		stores = append(stores, templateStore{Name: url, URL: url, Priority: cliURLPriorityBase + i})
+	}
+
+	if envStores := os.Getenv(templateStoreURLsEnvironment); envStores != "" {
+		for i, url := range strings.Split(envStores, ":") {
+			if url == "" {
+				continue
+			}
+			stores = append(stores, templateStore{Name: url, URL: url, Priority: envURLPriorityBase + i})
+		}
+	}
+
+	if legacyURL := os.Getenv(templateStoreURLEnvironment); legacyURL != "" {
+		stores = append(stores, templateStore{Name: legacyURL, URL: legacyURL, Priority: legacyURLPriority})
+	}
+
+	configured, err := loadStoresConfig()
+	if err != nil {
+		return nil, err
+	}
+	stores = append(stores, configured...)
+
+	if len(stores) == 0 {
+		stores = append(stores, templateStore{Name: "official", URL: DefaultTemplatesStore})
+	}
+
+	sortStoresByPriority(stores)
+
+	return stores, nil
+}
+
+// fetchTemplateStores fetches each configured store concurrently, then
+// merges the results in priority order, de-duplicating by (TemplateName,
+// Platform, Source). Collisions are resolved in favour of the
+// higher-priority store, with a warning emitted to stderr.
+func fetchTemplateStores(stores []templateStore) ([]TemplateInfo, error) {
+	type storeResult struct {
+		store     templateStore
+		templates []TemplateInfo
+		err       error
+	}
+
+	results := make([]storeResult, len(stores))
+
+	var wg sync.WaitGroup
+	for i, store := range stores {
+		wg.Add(1)
+		go func(i int, store templateStore) {
+			defer wg.Done()
+			templates, err := getTemplateInfo(store.URL)
+			results[i] = storeResult{store: store, templates: templates, err: err}
+		}(i, store)
+	}
+	wg.Wait()
+
+	merged := []TemplateInfo{}
+	winners := map[string]templateStore{}
+	fetchedAny := false
+	var lastErr error
+
+	for _, result := range results {
+		if result.err != nil {
+			fmt.Fprintf(os.Stderr, "warning: unable to fetch templates from store %q (%s): %s\n", result.store.Name, result.store.URL, result.err)
+			lastErr = result.err
+			continue
+		}
+		fetchedAny = true
+
+		for _, template := range result.templates {
+			key := templateDedupeKey(template)
+			if winner, exists := winners[key]; exists {
+				fmt.Fprintf(os.Stderr, "warning: template %q already provided by store %q, ignoring duplicate from %q\n",
+					template.TemplateName, winner.Name, result.store.Name)
+				continue
+			}
+			winners[key] = result.store
+			template.Store = result.store.Name
+			merged = append(merged, template)
+		}
+	}
+
+	if !fetchedAny {
+		return nil, fmt.Errorf("unable to fetch templates from any configured store: %s", lastErr)
+	}
+
+	return merged, nil
+}
+
+// templateDedupeKey identifies a template regardless of which store it came
+// from, so the same template published by multiple stores is only listed once.
+func templateDedupeKey(template TemplateInfo) string {
+	return template.TemplateName + "\x00" + template.Platform + "\x00" + template.Source
+}
+
+func filterTemplatesByPlatform(templates []TemplateInfo, platform string) []TemplateInfo {
+	filtered := []TemplateInfo{}
+	for _, template := range templates {
+		if template.Platform == platform {
+			filtered = append(filtered, template)
+		}
+	}
+	return filtered
+}
+
+func checkExistingOutputFormat(format string) error {
+	for _, availableOutput := range availableOutputs {
+		if availableOutput == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("\nCurrently supported output formats are: table, json, yaml and wide. Unable to find: %s\n\n", format)
+}
+
+// getTemplateInfo fetches the template store catalog from repository,
+// transparently caching it under the directory returned by
+// templateStoreCacheDir. Set the package-level offlineCache flag to use the
+// cache only, or refreshCache to bypass it, and cacheTTL to control how long
+// a cached copy is considered fresh. Whenever --checksum/--keyring is
+// configured, every path that can return cached content - offline, TTL-fresh
+// and 304 Not Modified - re-verifies it via verifyCachedEntry, so a stale or
+// tampered cache can't bypass verification just because it wasn't re-fetched.
 func getTemplateInfo(repository string) ([]TemplateInfo, error) {
+	cacheEntry, cacheErr := loadTemplateStoreCache(repository)
+
+	if offlineCache {
+		if cacheErr != nil {
+			return nil, fmt.Errorf("--offline was set but no usable cache was found for %s: %s", repository, cacheErr)
+		}
+		if err := verifyCachedEntry(cacheEntry, repository); err != nil {
+			return nil, err
+		}
+		return cacheEntry.Templates, nil
+	}
+
+	if !refreshCache && cacheErr == nil && time.Since(cacheEntry.FetchedAt) < cacheTTL {
+		if err := verifyCachedEntry(cacheEntry, repository); err != nil {
+			return nil, err
+		}
+		return cacheEntry.Templates, nil
+	}
+
 	req, reqErr := http.NewRequest(http.MethodGet, repository, nil)
 	if reqErr != nil {
 		return nil, fmt.Errorf("error while trying to create request to take template info: %s", reqErr.Error())
@@ -77,6 +272,15 @@ func getTemplateInfo(repository string) ([]TemplateInfo, error) {
 	defer cancel()
 	req = req.WithContext(reqContext)
 
+	if !refreshCache && cacheErr == nil {
+		if cacheEntry.ETag != "" {
+			req.Header.Set("If-None-Match", cacheEntry.ETag)
+		}
+		if cacheEntry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cacheEntry.LastModified)
+		}
+	}
+
 	client := http.DefaultClient
 	res, clientErr := client.Do(req)
 	if clientErr != nil {
@@ -84,10 +288,24 @@ func getTemplateInfo(repository string) ([]TemplateInfo, error) {
 	}
 
 	if res.Body == nil {
-		return nil, fmt.Errorf("error empty response body from: %s", templateStoreURL)
+		return nil, fmt.Errorf("error empty response body from: %s", repository)
 	}
 	defer res.Body.Close()
 
+	if res.StatusCode == http.StatusNotModified {
+		if cacheErr != nil {
+			return nil, fmt.Errorf("received 304 Not Modified from %s but no cache was found: %s", repository, cacheErr)
+		}
+		if err := verifyCachedEntry(cacheEntry, repository); err != nil {
+			return nil, err
+		}
+		cacheEntry.FetchedAt = time.Now()
+		if saveErr := saveTemplateStoreCache(repository, cacheEntry); saveErr != nil {
+			fmt.Fprintf(os.Stderr, "warning: unable to update template store cache: %s\n", saveErr)
+		}
+		return cacheEntry.Templates, nil
+	}
+
 	if res.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code wanted: %d got: %d", http.StatusOK, res.StatusCode)
 	}
@@ -97,95 +315,129 @@ func getTemplateInfo(repository string) ([]TemplateInfo, error) {
 		return nil, fmt.Errorf("error while reading data from templates body: %s", bodyErr.Error())
 	}
 
+	if verifyErr := verifyTemplatesBody(body, repository); verifyErr != nil {
+		return nil, verifyErr
+	}
+
 	templatesInfo := []TemplateInfo{}
 	unmarshallErr := json.Unmarshal(body, &templatesInfo)
 	if unmarshallErr != nil {
 		return nil, fmt.Errorf("error while unmarshalling into templates struct: %s", unmarshallErr.Error())
 	}
+
+	newEntry := &templateStoreCacheEntry{
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+		FetchedAt:    time.Now(),
+		Templates:    templatesInfo,
+		RawBody:      body,
+	}
+	if saveErr := saveTemplateStoreCache(repository, newEntry); saveErr != nil {
+		fmt.Fprintf(os.Stderr, "warning: unable to save template store cache: %s\n", saveErr)
+	}
+
 	return templatesInfo, nil
 }
 
-func formatTemplatesOutput(templates []TemplateInfo, verbose bool, platform string) string {
-	if platform != allPlatforms {
-		err := checkExistingPlatforms(platform)
+// formatTemplatesOutput renders templates as table, json, yaml or wide,
+// depending on format. verbose only affects the table format: json and yaml
+// always include every field, and wide always shows every column except
+// SHA256.
+func formatTemplatesOutput(templates []TemplateInfo, verbose bool, format string) (string, error) {
+	if err := checkExistingOutputFormat(format); err != nil {
+		return "", err
+	}
+
+	switch format {
+	case jsonOutputFormat:
+		out, err := json.MarshalIndent(templates, "", "  ")
 		if err != nil {
-			return err.Error()
+			return "", fmt.Errorf("error while marshalling templates to json: %s", err.Error())
 		}
+		return string(out) + "\n", nil
+	case yamlOutputFormat:
+		out, err := yaml.Marshal(templates)
+		if err != nil {
+			return "", fmt.Errorf("error while marshalling templates to yaml: %s", err.Error())
+		}
+		return string(out), nil
 	}
 
 	var buff bytes.Buffer
 	lineWriter := tabwriter.NewWriter(&buff, 0, 0, 1, ' ', 0)
 
 	fmt.Fprintln(lineWriter)
-	if verbose {
-		formatVerboseOutput(lineWriter, templates, platform)
+	if format == wideOutputFormat {
+		formatWideOutput(lineWriter, templates)
+	} else if verbose {
+		formatVerboseOutput(lineWriter, templates)
 	} else {
-		formatBasicOutput(lineWriter, templates, platform)
+		formatBasicOutput(lineWriter, templates)
 	}
 	fmt.Fprintln(lineWriter)
 
 	lineWriter.Flush()
 
-	return buff.String()
+	return buff.String(), nil
 }
 
-func formatBasicOutput(lineWriter *tabwriter.Writer, templates []TemplateInfo, platform string) {
-	if platform != allPlatforms {
-		fmt.Fprintf(lineWriter, "NAME\tSOURCE\tDESCRIPTION\n")
-		for _, template := range templates {
-			if template.Platform == platform {
-				fmt.Fprintf(lineWriter, "%s\t%s\t%s\n",
-					template.TemplateName,
-					template.Source,
-					template.Description)
-			}
-		}
-	} else {
-		fmt.Fprintf(lineWriter, "NAME\tSOURCE\tDESCRIPTION\n")
-		for _, template := range templates {
-			fmt.Fprintf(lineWriter, "%s\t%s\t%s\n",
-				template.TemplateName,
-				template.Source,
-				template.Description)
-		}
+func formatBasicOutput(lineWriter *tabwriter.Writer, templates []TemplateInfo) {
+	fmt.Fprintf(lineWriter, "NAME\tSOURCE\tDESCRIPTION\n")
+	for _, template := range templates {
+		fmt.Fprintf(lineWriter, "%s\t%s\t%s\n",
+			template.TemplateName,
+			template.Source,
+			template.Description)
 	}
 }
 
-func formatVerboseOutput(lineWriter *tabwriter.Writer, templates []TemplateInfo, platform string) {
-	if platform != allPlatforms {
-		fmt.Fprintf(lineWriter, "NAME\tLANGUAGE\tPLATFORM\tSOURCE\tDESCRIPTION\n")
-		for _, template := range templates {
-			if template.Platform == platform {
-				fmt.Fprintf(lineWriter, "%s\t%s\t%s\t%s\t%s\n",
-					template.TemplateName,
-					template.Language,
-					template.Platform,
-					template.Source,
-					template.Description)
-			}
-		}
-	} else {
-		fmt.Fprintf(lineWriter, "NAME\tLANGUAGE\tPLATFORM\tSOURCE\tDESCRIPTION\n")
-		for _, template := range templates {
-			fmt.Fprintf(lineWriter, "%s\t%s\t%s\t%s\t%s\n",
-				template.TemplateName,
-				template.Language,
-				template.Platform,
-				template.Source,
-				template.Description)
-		}
+func formatVerboseOutput(lineWriter *tabwriter.Writer, templates []TemplateInfo) {
+	fmt.Fprintf(lineWriter, "NAME\tLANGUAGE\tPLATFORM\tSOURCE\tDESCRIPTION\tSTORE\n")
+	for _, template := range templates {
+		fmt.Fprintf(lineWriter, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			template.TemplateName,
+			template.Language,
+			template.Platform,
+			template.Source,
+			template.Description,
+			template.Store)
+	}
+}
+
+func formatWideOutput(lineWriter *tabwriter.Writer, templates []TemplateInfo) {
+	fmt.Fprintf(lineWriter, "NAME\tLANGUAGE\tPLATFORM\tSOURCE\tDESCRIPTION\tREPO\tOFFICIAL\tSTORE\n")
+	for _, template := range templates {
+		fmt.Fprintf(lineWriter, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			template.TemplateName,
+			template.Language,
+			template.Platform,
+			template.Source,
+			template.Description,
+			template.Repository,
+			template.Official,
+			template.Store)
 	}
 }
 
 // TemplateInfo is the definition of a template which is part of the store
 type TemplateInfo struct {
-	TemplateName string `json:"template"`
-	Platform     string `json:"platform"`
-	Language     string `json:"language"`
-	Source       string `json:"source"`
-	Description  string `json:"description"`
-	Repository   string `json:"repo"`
-	Official     string `json:"official"`
+	TemplateName string `json:"template" yaml:"template"`
+	Platform     string `json:"platform" yaml:"platform"`
+	Language     string `json:"language" yaml:"language"`
+	Source       string `json:"source" yaml:"source"`
+	Description  string `json:"description" yaml:"description"`
+	Repository   string `json:"repo" yaml:"repo"`
+	Official     string `json:"official" yaml:"official"`
+
+	// SHA256 is the checksum of the template's tarball, allowing
+	// "faas-cli template store pull" to verify it at pull time. It is
+	// omitted by stores that do not publish per-template checksums.
+	SHA256 string `json:"sha256,omitempty" yaml:"sha256,omitempty"`
+
+	// Store is the name of the configured template store this entry was
+	// fetched from. It is populated by fetchTemplateStores and is not part
+	// of the upstream templates.json schema.
+	Store string `json:"store,omitempty" yaml:"store,omitempty"`
 }
 
 func checkExistingPlatforms(platform string) error {
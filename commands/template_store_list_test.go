@@ -0,0 +1,82 @@
+// Copyright (c) OpenFaaS Author(s) 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func sampleTemplates() []TemplateInfo {
+	return []TemplateInfo{
+		{TemplateName: "golang-http", Platform: "x86_64", Language: "go", Source: "openfaas", Description: "Classic watchdog", Repository: "openfaas/templates", Official: "true"},
+		{TemplateName: "node12-express", Platform: "armhf", Language: "node", Source: "openfaas", Description: "Express.js", Repository: "openfaas/templates", Official: "false"},
+	}
+}
+
+func TestFormatTemplatesOutputTable(t *testing.T) {
+	out, err := formatTemplatesOutput(sampleTemplates(), false, tableOutputFormat)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, want := range []string{"NAME", "SOURCE", "DESCRIPTION"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected basic table header to contain %q, got: %s", want, out)
+		}
+	}
+	if strings.Contains(out, "REPO") {
+		t.Fatalf("non-verbose table output should not contain REPO column: %s", out)
+	}
+}
+
+func TestFormatTemplatesOutputWideIncludesRepoOfficialAndStore(t *testing.T) {
+	templates := sampleTemplates()
+	templates[0].Store = "official"
+
+	out, err := formatTemplatesOutput(templates, false, wideOutputFormat)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, want := range []string{"REPO", "OFFICIAL", "STORE", "official"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected wide output to contain %q, got: %s", want, out)
+		}
+	}
+}
+
+func TestFormatTemplatesOutputJSON(t *testing.T) {
+	out, err := formatTemplatesOutput(sampleTemplates(), false, jsonOutputFormat)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(out, `"template": "golang-http"`) {
+		t.Fatalf("expected json output to include the template name, got: %s", out)
+	}
+	if !strings.Contains(out, `"repo": "openfaas/templates"`) {
+		t.Fatalf("expected json output to include the repo field, got: %s", out)
+	}
+}
+
+func TestFormatTemplatesOutputYAML(t *testing.T) {
+	out, err := formatTemplatesOutput(sampleTemplates(), false, yamlOutputFormat)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(out, "template: golang-http") {
+		t.Fatalf("expected yaml output to include the template name, got: %s", out)
+	}
+}
+
+func TestFormatTemplatesOutputRejectsUnknownFormat(t *testing.T) {
+	if _, err := formatTemplatesOutput(sampleTemplates(), false, "csv"); err == nil {
+		t.Fatal("expected an error for an unsupported output format")
+	}
+}
+
+func TestFilterTemplatesByPlatform(t *testing.T) {
+	filtered := filterTemplatesByPlatform(sampleTemplates(), "armhf")
+	if len(filtered) != 1 || filtered[0].TemplateName != "node12-express" {
+		t.Fatalf("expected only the armhf template to remain, got: %+v", filtered)
+	}
+}
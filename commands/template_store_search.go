@@ -0,0 +1,145 @@
+// Copyright (c) OpenFaaS Author(s) 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	searchLanguage string
+	searchOfficial bool
+	searchRegex    bool
+)
+
+func init() {
+	templateStoreSearchCmd.Flags().StringArrayVarP(&templateStoreURLs, "url", "u", []string{}, "Use as alternative store for templates, can be repeated to query several stores")
+	templateStoreSearchCmd.Flags().StringVarP(&platform, "platform", "p", allPlatforms, "Only match templates for this platform")
+	templateStoreSearchCmd.Flags().StringVar(&searchLanguage, "language", "", "Only match templates for this language")
+	templateStoreSearchCmd.Flags().BoolVar(&searchOfficial, "official", false, "Only match official templates")
+	templateStoreSearchCmd.Flags().BoolVar(&searchRegex, "regex", false, "Treat the query as a regular expression instead of a substring match")
+	templateStoreSearchCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Shows additional language and platform")
+	templateStoreSearchCmd.Flags().StringVarP(&outputFormat, "output", "o", tableOutputFormat, "Output format, one of: table|json|yaml|wide")
+
+	templateStoreCmd.AddCommand(templateStoreSearchCmd)
+}
+
+// templateStoreSearchCmd filters the template store catalog by a substring
+// or regular expression, so the growing store can be navigated without
+// piping "template store list" through grep.
+var templateStoreSearchCmd = &cobra.Command{
+	Use:   `search QUERY`,
+	Short: `Search for templates in the store`,
+	Long:  `Search the official store, or a custom URL, for templates whose name, language, description or source match QUERY. Exits non-zero when nothing matches, so it can be used as a CI gate.`,
+	Example: `  faas-cli template store search go
+  faas-cli template store search --language python http
+  faas-cli template store search --official template
+  faas-cli template store search --regex '^golang-.*-http$'`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTemplateStoreSearch,
+}
+
+func runTemplateStoreSearch(cmd *cobra.Command, args []string) error {
+	query := args[0]
+
+	stores, storesErr := resolveTemplateStores(templateStoreURLs)
+	if storesErr != nil {
+		return fmt.Errorf("error while resolving template stores: %s", storesErr)
+	}
+
+	templatesInfo, templatesErr := fetchTemplateStores(stores)
+	if templatesErr != nil {
+		return fmt.Errorf("error while getting templates info: %s", templatesErr)
+	}
+
+	if platform != allPlatforms {
+		if err := checkExistingPlatforms(platform); err != nil {
+			return err
+		}
+		templatesInfo = filterTemplatesByPlatform(templatesInfo, platform)
+	}
+
+	if searchLanguage != "" {
+		templatesInfo = filterTemplatesByLanguage(templatesInfo, searchLanguage)
+	}
+
+	if searchOfficial {
+		templatesInfo = filterOfficialTemplates(templatesInfo)
+	}
+
+	matched, matchErr := filterTemplatesByQuery(templatesInfo, query, searchRegex)
+	if matchErr != nil {
+		return matchErr
+	}
+
+	if len(matched) == 0 {
+		return fmt.Errorf("no templates matched query: %s", query)
+	}
+
+	formattedOutput, err := formatTemplatesOutput(matched, verbose, outputFormat)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "%s", formattedOutput)
+
+	return nil
+}
+
+func filterTemplatesByLanguage(templates []TemplateInfo, language string) []TemplateInfo {
+	filtered := []TemplateInfo{}
+	for _, template := range templates {
+		if strings.EqualFold(template.Language, language) {
+			filtered = append(filtered, template)
+		}
+	}
+	return filtered
+}
+
+func filterOfficialTemplates(templates []TemplateInfo) []TemplateInfo {
+	filtered := []TemplateInfo{}
+	for _, template := range templates {
+		if strings.EqualFold(template.Official, "true") {
+			filtered = append(filtered, template)
+		}
+	}
+	return filtered
+}
+
+// filterTemplatesByQuery matches query against TemplateName, Language,
+// Description and Source, case-insensitively unless useRegex is set, in
+// which case query is compiled as a regular expression and matched as-is
+// against the same fields.
+func filterTemplatesByQuery(templates []TemplateInfo, query string, useRegex bool) ([]TemplateInfo, error) {
+	var matches func(string) bool
+
+	if useRegex {
+		re, err := regexp.Compile(query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --regex query: %s", err)
+		}
+		matches = re.MatchString
+	} else {
+		lowerQuery := strings.ToLower(query)
+		matches = func(field string) bool {
+			return strings.Contains(strings.ToLower(field), lowerQuery)
+		}
+	}
+
+	filtered := []TemplateInfo{}
+	for _, template := range templates {
+		if matches(template.TemplateName) ||
+			matches(template.Language) ||
+			matches(template.Description) ||
+			matches(template.Source) {
+			filtered = append(filtered, template)
+		}
+	}
+
+	return filtered, nil
+}
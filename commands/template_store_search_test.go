@@ -0,0 +1,62 @@
+// Copyright (c) OpenFaaS Author(s) 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import "testing"
+
+func TestFilterTemplatesByQuerySubstringIsCaseInsensitive(t *testing.T) {
+	templates := sampleTemplates()
+
+	matched, err := filterTemplatesByQuery(templates, "HTTP", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(matched) != 1 || matched[0].TemplateName != "golang-http" {
+		t.Fatalf("expected only golang-http to match, got: %+v", matched)
+	}
+}
+
+func TestFilterTemplatesByQueryMatchesAcrossFields(t *testing.T) {
+	templates := sampleTemplates()
+
+	matched, err := filterTemplatesByQuery(templates, "Express.js", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(matched) != 1 || matched[0].TemplateName != "node12-express" {
+		t.Fatalf("expected the description match to find node12-express, got: %+v", matched)
+	}
+}
+
+func TestFilterTemplatesByQueryRegex(t *testing.T) {
+	templates := sampleTemplates()
+
+	matched, err := filterTemplatesByQuery(templates, "^golang-.*$", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(matched) != 1 || matched[0].TemplateName != "golang-http" {
+		t.Fatalf("expected the regex to match only golang-http, got: %+v", matched)
+	}
+}
+
+func TestFilterTemplatesByQueryInvalidRegex(t *testing.T) {
+	if _, err := filterTemplatesByQuery(sampleTemplates(), "(unclosed", true); err == nil {
+		t.Fatal("expected an error for an invalid regular expression")
+	}
+}
+
+func TestFilterTemplatesByLanguage(t *testing.T) {
+	matched := filterTemplatesByLanguage(sampleTemplates(), "Go")
+	if len(matched) != 1 || matched[0].TemplateName != "golang-http" {
+		t.Fatalf("expected a case-insensitive match on language, got: %+v", matched)
+	}
+}
+
+func TestFilterOfficialTemplates(t *testing.T) {
+	matched := filterOfficialTemplates(sampleTemplates())
+	if len(matched) != 1 || matched[0].TemplateName != "golang-http" {
+		t.Fatalf("expected only the official=true template to remain, got: %+v", matched)
+	}
+}
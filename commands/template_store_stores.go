@@ -0,0 +1,237 @@
+// Copyright (c) OpenFaaS Author(s) 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// storesConfigFileName is the name of the file, under the OpenFaaS config
+// directory, that persists additional template stores added with
+// "faas-cli template store add".
+const storesConfigFileName = "stores.yaml"
+
+// templateStore is a single template store, either supplied ad-hoc via
+// --url/OPENFAAS_TEMPLATE_STORES or persisted in stores.yaml. Priority
+// determines query and collision-resolution order across ALL sources
+// combined: lower values are queried, and win name collisions, ahead of
+// higher values. Ad-hoc sources are assigned a default Priority (see the
+// cliURLPriorityBase, envURLPriorityBase and legacyURLPriority constants
+// below) so that, unless a stores.yaml entry is
+// explicitly given a lower --priority than that, the previous ad-hoc-wins
+// behaviour is preserved.
+type templateStore struct {
+	Name     string `yaml:"name"`
+	URL      string `yaml:"url"`
+	Priority int    `yaml:"priority"`
+}
+
+// Default priorities for ad-hoc store sources, most to least preferred.
+// stores.yaml entries default to Priority 0, so they are queried and win
+// collisions after every ad-hoc source unless explicitly given a lower
+// --priority than these.
+const (
+	cliURLPriorityBase = -30000
+	envURLPriorityBase = -20000
+	legacyURLPriority  = -10000
+)
+
+// storesConfig is the on-disk schema of stores.yaml.
+type storesConfig struct {
+	Stores []templateStore `yaml:"stores"`
+}
+
+var storeAddPriority int
+
+func init() {
+	templateStoreAddCmd.Flags().IntVar(&storeAddPriority, "priority", 0, fmt.Sprintf("Priority of this store relative to every other configured store, lower values are queried and win name collisions first; ad-hoc --url/env stores default to %d..%d, so a priority below that is needed to outrank them", cliURLPriorityBase, legacyURLPriority))
+
+	templateStoreCmd.AddCommand(templateStoreAddCmd)
+	templateStoreCmd.AddCommand(templateStoreRemoveCmd)
+	templateStoreCmd.AddCommand(templateStoreListStoresCmd)
+}
+
+var templateStoreAddCmd = &cobra.Command{
+	Use:   `add NAME URL`,
+	Short: `Add a template store`,
+	Long: `Add a template store to ~/.openfaas/stores.yaml so it is queried by every "faas-cli template store" command.
+
+Priority is compared across ALL sources together, not just other stores.yaml
+entries: ad-hoc --url flags and the OPENFAAS_TEMPLATE_STORES/
+OPENFAAS_TEMPLATE_STORE_URL environment variables default to priorities in
+the -30000..-10000 range so they win by default. Pass --priority below that
+range to have a configured store outrank them.`,
+	Example: `  faas-cli template store add internal https://templates.example.com/templates.json --priority 10
+  faas-cli template store add internal https://templates.example.com/templates.json --priority -40000`,
+	Args: cobra.ExactArgs(2),
+	RunE: runTemplateStoreAdd,
+}
+
+var templateStoreRemoveCmd = &cobra.Command{
+	Use:     `remove NAME`,
+	Short:   `Remove a configured template store`,
+	Long:    `Remove a template store previously added with "faas-cli template store add" from ~/.openfaas/stores.yaml`,
+	Example: `  faas-cli template store remove internal`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runTemplateStoreRemove,
+}
+
+var templateStoreListStoresCmd = &cobra.Command{
+	Use:     `list-stores`,
+	Short:   `List configured template stores`,
+	Long:    `List the template stores configured in ~/.openfaas/stores.yaml`,
+	Example: `  faas-cli template store list-stores`,
+	RunE:    runTemplateStoreListStores,
+}
+
+func runTemplateStoreAdd(cmd *cobra.Command, args []string) error {
+	name, url := args[0], args[1]
+
+	config, err := readStoresConfig()
+	if err != nil {
+		return err
+	}
+
+	for _, store := range config.Stores {
+		if store.Name == name {
+			return fmt.Errorf("a store named %q is already configured with url: %s", name, store.URL)
+		}
+	}
+
+	config.Stores = append(config.Stores, templateStore{Name: name, URL: url, Priority: storeAddPriority})
+
+	if err := writeStoresConfig(config); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Added store %q: %s\n", name, url)
+	return nil
+}
+
+func runTemplateStoreRemove(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	config, err := readStoresConfig()
+	if err != nil {
+		return err
+	}
+
+	filtered := config.Stores[:0]
+	removed := false
+	for _, store := range config.Stores {
+		if store.Name == name {
+			removed = true
+			continue
+		}
+		filtered = append(filtered, store)
+	}
+	config.Stores = filtered
+
+	if !removed {
+		return fmt.Errorf("no store named %q is configured", name)
+	}
+
+	if err := writeStoresConfig(config); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Removed store %q\n", name)
+	return nil
+}
+
+func runTemplateStoreListStores(cmd *cobra.Command, args []string) error {
+	config, err := readStoresConfig()
+	if err != nil {
+		return err
+	}
+
+	if len(config.Stores) == 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "No template stores configured, using the official store: %s\n", DefaultTemplatesStore)
+		return nil
+	}
+
+	for _, store := range config.Stores {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\tpriority: %d\n", store.Name, store.URL, store.Priority)
+	}
+
+	return nil
+}
+
+// loadStoresConfig returns the stores configured in stores.yaml, sorted by
+// ascending priority, for use when resolving which stores to query.
+func loadStoresConfig() ([]templateStore, error) {
+	config, err := readStoresConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	stores := make([]templateStore, len(config.Stores))
+	copy(stores, config.Stores)
+	sortStoresByPriority(stores)
+
+	return stores, nil
+}
+
+func sortStoresByPriority(stores []templateStore) {
+	for i := 1; i < len(stores); i++ {
+		for j := i; j > 0 && stores[j].Priority < stores[j-1].Priority; j-- {
+			stores[j], stores[j-1] = stores[j-1], stores[j]
+		}
+	}
+}
+
+func storesConfigPath() (string, error) {
+	dir, err := openfaasConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, storesConfigFileName), nil
+}
+
+func readStoresConfig() (*storesConfig, error) {
+	path, err := storesConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &storesConfig{}, nil
+		}
+		return nil, fmt.Errorf("unable to read %s: %s", path, err)
+	}
+
+	config := &storesConfig{}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %s", path, err)
+	}
+
+	return config, nil
+}
+
+func writeStoresConfig(config *storesConfig) error {
+	path, err := storesConfigPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("unable to marshal stores config: %s", err)
+	}
+
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("unable to write %s: %s", path, err)
+	}
+
+	return nil
+}
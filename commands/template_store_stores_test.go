@@ -0,0 +1,139 @@
+// Copyright (c) OpenFaaS Author(s) 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTemplatesServer(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+}
+
+func TestFetchTemplateStoresMergesAndDedupes(t *testing.T) {
+	defer withTempHome(t)()
+	resetTemplateStoreFlags()
+	defer resetTemplateStoreFlags()
+
+	high := newTemplatesServer(t, `[{"template":"golang-http","platform":"x86_64","description":"from high priority"}]`)
+	defer high.Close()
+
+	low := newTemplatesServer(t, `[
+		{"template":"golang-http","platform":"x86_64","description":"from low priority"},
+		{"template":"node12-express","platform":"x86_64","description":"unique to low priority"}
+	]`)
+	defer low.Close()
+
+	stores := []templateStore{
+		{Name: "high", URL: high.URL, Priority: -100},
+		{Name: "low", URL: low.URL, Priority: 0},
+	}
+
+	merged, err := fetchTemplateStores(stores)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(merged) != 2 {
+		t.Fatalf("expected the duplicate golang-http to be de-duplicated, got %d templates: %+v", len(merged), merged)
+	}
+
+	for _, template := range merged {
+		if template.TemplateName == "golang-http" {
+			if template.Store != "high" {
+				t.Fatalf("expected the higher priority store to win the collision, got store %q", template.Store)
+			}
+			if template.Description != "from high priority" {
+				t.Fatalf("expected the higher priority store's copy to be kept, got description %q", template.Description)
+			}
+		}
+	}
+}
+
+func TestFetchTemplateStoresContinuesWhenOneStoreFails(t *testing.T) {
+	defer withTempHome(t)()
+	resetTemplateStoreFlags()
+	defer resetTemplateStoreFlags()
+
+	ok := newTemplatesServer(t, `[{"template":"golang-http","platform":"x86_64"}]`)
+	defer ok.Close()
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+
+	stores := []templateStore{
+		{Name: "ok", URL: ok.URL},
+		{Name: "broken", URL: broken.URL},
+	}
+
+	merged, err := fetchTemplateStores(stores)
+	if err != nil {
+		t.Fatalf("expected one healthy store to be enough, got error: %s", err)
+	}
+	if len(merged) != 1 {
+		t.Fatalf("expected only the healthy store's template, got %+v", merged)
+	}
+}
+
+func TestFetchTemplateStoresErrorsWhenAllFail(t *testing.T) {
+	defer withTempHome(t)()
+	resetTemplateStoreFlags()
+	defer resetTemplateStoreFlags()
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+
+	stores := []templateStore{{Name: "broken", URL: broken.URL}}
+
+	if _, err := fetchTemplateStores(stores); err == nil {
+		t.Fatal("expected an error when every configured store fails")
+	}
+}
+
+func TestResolveTemplateStoresPriorityIsGlobalAcrossSources(t *testing.T) {
+	defer withTempHome(t)()
+	resetTemplateStoreFlags()
+	defer resetTemplateStoreFlags()
+
+	// By default, an ad-hoc --url should be queried ahead of any
+	// stores.yaml entry.
+	if err := writeStoresConfig(&storesConfig{Stores: []templateStore{
+		{Name: "configured", URL: "https://configured.example.com/templates.json", Priority: 0},
+	}}); err != nil {
+		t.Fatalf("unable to seed stores.yaml: %s", err)
+	}
+
+	stores, err := resolveTemplateStores([]string{"https://adhoc.example.com/templates.json"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if stores[0].URL != "https://adhoc.example.com/templates.json" {
+		t.Fatalf("expected the ad-hoc --url to default ahead of stores.yaml, got order: %+v", stores)
+	}
+
+	// A stores.yaml entry given a sufficiently low --priority must be able
+	// to outrank the ad-hoc --url.
+	if err := writeStoresConfig(&storesConfig{Stores: []templateStore{
+		{Name: "configured", URL: "https://configured.example.com/templates.json", Priority: cliURLPriorityBase - 1},
+	}}); err != nil {
+		t.Fatalf("unable to seed stores.yaml: %s", err)
+	}
+
+	stores, err = resolveTemplateStores([]string{"https://adhoc.example.com/templates.json"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if stores[0].URL != "https://configured.example.com/templates.json" {
+		t.Fatalf("expected a stores.yaml entry with a lower priority than the ad-hoc default to win, got order: %+v", stores)
+	}
+}
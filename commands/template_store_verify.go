@@ -0,0 +1,143 @@
+// Copyright (c) OpenFaaS Author(s) 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// templateStoreKeyringEnvironment points at an armored OpenPGP public
+// keyring, the environment counterpart of --keyring.
+const templateStoreKeyringEnvironment = "OPENFAAS_TEMPLATE_STORE_KEYRING"
+
+var (
+	templateStoreChecksum string
+	templateStoreKeyring  string
+)
+
+func init() {
+	templateStoreListCmd.PersistentFlags().StringVar(&templateStoreChecksum, "checksum", "", "Verify the fetched templates.json against this sha256 checksum")
+	templateStoreListCmd.PersistentFlags().StringVar(&templateStoreKeyring, "keyring", "", "Path to an armored OpenPGP public keyring used to verify templates.json.sig")
+
+	// templateStoreSearchCmd goes through the exact same
+	// fetchTemplateStores -> getTemplateInfo -> verifyTemplatesBody path as
+	// templateStoreListCmd, so it needs the same verification flags.
+	templateStoreSearchCmd.Flags().StringVar(&templateStoreChecksum, "checksum", "", "Verify the fetched templates.json against this sha256 checksum")
+	templateStoreSearchCmd.Flags().StringVar(&templateStoreKeyring, "keyring", "", "Path to an armored OpenPGP public keyring used to verify templates.json.sig")
+}
+
+// effectiveKeyringPath resolves --keyring, falling back to
+// OPENFAAS_TEMPLATE_STORE_KEYRING.
+func effectiveKeyringPath() string {
+	if templateStoreKeyring != "" {
+		return templateStoreKeyring
+	}
+	return os.Getenv(templateStoreKeyringEnvironment)
+}
+
+// verificationConfigured reports whether --checksum or --keyring (or its
+// environment variable) is set, meaning unverified content must be rejected
+// whether it was just fetched or is being served from the local cache.
+func verificationConfigured() bool {
+	return templateStoreChecksum != "" || effectiveKeyringPath() != ""
+}
+
+// verifyTemplatesBody checks body, the raw bytes of a templates.json
+// response, against the configured --checksum and/or --keyring before it is
+// unmarshalled. Unverified content is rejected by default whenever either is
+// configured.
+func verifyTemplatesBody(body []byte, repository string) error {
+	if templateStoreChecksum != "" {
+		sum := sha256.Sum256(body)
+		got := hex.EncodeToString(sum[:])
+		if !strings.EqualFold(got, templateStoreChecksum) {
+			return fmt.Errorf("checksum verification failed for %s: wanted %s, got %s", repository, templateStoreChecksum, got)
+		}
+	}
+
+	if keyringPath := effectiveKeyringPath(); keyringPath != "" {
+		if err := verifyTemplatesSignature(body, repository, keyringPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// verifyCachedEntry re-applies verifyTemplatesBody to a cache entry being
+// served without a live fetch (the --offline, TTL-fresh and 304 paths in
+// getTemplateInfo), so a cache written before --checksum/--keyring was
+// configured - or tampered with on disk - can't silently bypass verification.
+func verifyCachedEntry(entry *templateStoreCacheEntry, repository string) error {
+	if !verificationConfigured() {
+		return nil
+	}
+
+	if len(entry.RawBody) == 0 {
+		return fmt.Errorf("--checksum/--keyring is set but the cached copy of %s predates verification support, re-run with --refresh", repository)
+	}
+
+	return verifyTemplatesBody(entry.RawBody, repository)
+}
+
+// verifyTemplatesSignature fetches the detached signature from
+// "<repository>.sig" and verifies it against body using the armored public
+// keyring at keyringPath.
+func verifyTemplatesSignature(body []byte, repository, keyringPath string) error {
+	keyringFile, err := os.Open(keyringPath)
+	if err != nil {
+		return fmt.Errorf("unable to open keyring %s: %s", keyringPath, err)
+	}
+	defer keyringFile.Close()
+
+	keyring, err := openpgp.ReadArmoredKeyRing(keyringFile)
+	if err != nil {
+		return fmt.Errorf("unable to read keyring %s: %s", keyringPath, err)
+	}
+
+	signature, err := fetchTemplatesSignature(repository + ".sig")
+	if err != nil {
+		return fmt.Errorf("unable to fetch signature for %s: %s", repository, err)
+	}
+
+	if _, err := openpgp.CheckDetachedSignature(keyring, bytes.NewReader(body), bytes.NewReader(signature)); err != nil {
+		return fmt.Errorf("signature verification failed for %s: %s", repository, err)
+	}
+
+	return nil
+}
+
+func fetchTemplatesSignature(sigURL string) ([]byte, error) {
+	req, reqErr := http.NewRequest(http.MethodGet, sigURL, nil)
+	if reqErr != nil {
+		return nil, fmt.Errorf("error while trying to create request for signature: %s", reqErr.Error())
+	}
+
+	reqContext, cancel := context.WithTimeout(req.Context(), 5*time.Second)
+	defer cancel()
+	req = req.WithContext(reqContext)
+
+	res, clientErr := http.DefaultClient.Do(req)
+	if clientErr != nil {
+		return nil, fmt.Errorf("error while requesting signature: %s", clientErr.Error())
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code wanted: %d got: %d", http.StatusOK, res.StatusCode)
+	}
+
+	return ioutil.ReadAll(res.Body)
+}
@@ -0,0 +1,95 @@
+// Copyright (c) OpenFaaS Author(s) 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVerifyTemplatesBodyChecksumMatch(t *testing.T) {
+	resetTemplateStoreFlags()
+	defer resetTemplateStoreFlags()
+
+	body := []byte(`[{"template":"golang-http"}]`)
+	sum := sha256.Sum256(body)
+	templateStoreChecksum = hex.EncodeToString(sum[:])
+
+	if err := verifyTemplatesBody(body, "https://example.com/templates.json"); err != nil {
+		t.Fatalf("expected a matching checksum to pass verification, got: %s", err)
+	}
+}
+
+func TestVerifyTemplatesBodyChecksumMismatch(t *testing.T) {
+	resetTemplateStoreFlags()
+	defer resetTemplateStoreFlags()
+
+	templateStoreChecksum = "0000000000000000000000000000000000000000000000000000000000000000"
+
+	err := verifyTemplatesBody([]byte(`[{"template":"golang-http"}]`), "https://example.com/templates.json")
+	if err == nil {
+		t.Fatal("expected a checksum mismatch to be rejected")
+	}
+}
+
+// TestGetTemplateInfoReVerifiesCachedCopy reproduces the bug reported in
+// review: a cache entry written while --checksum was configured must still
+// be re-checked on every subsequent call that serves it without a live
+// fetch (TTL-fresh here), not just on the path that just downloaded it.
+func TestGetTemplateInfoReVerifiesCachedCopy(t *testing.T) {
+	defer withTempHome(t)()
+	resetTemplateStoreFlags()
+	defer resetTemplateStoreFlags()
+
+	body := []byte(`[{"template":"golang-http","platform":"x86_64"}]`)
+	sum := sha256.Sum256(body)
+	goodChecksum := hex.EncodeToString(sum[:])
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	templateStoreChecksum = goodChecksum
+	if _, err := getTemplateInfo(server.URL); err != nil {
+		t.Fatalf("unexpected error populating the cache: %s", err)
+	}
+
+	// Simulate the cached checksum no longer being trusted (e.g. the
+	// operator pins a different, tighter checksum for a later CI run) and
+	// confirm the TTL-fresh cache path rejects it instead of silently
+	// serving the unverified cached templates.
+	templateStoreChecksum = "0000000000000000000000000000000000000000000000000000000000000000"
+
+	if _, err := getTemplateInfo(server.URL); err == nil {
+		t.Fatal("expected the TTL-fresh cache path to re-verify against the configured checksum and fail")
+	}
+}
+
+func TestGetTemplateInfoOfflineReVerifiesCachedCopy(t *testing.T) {
+	defer withTempHome(t)()
+	resetTemplateStoreFlags()
+	defer resetTemplateStoreFlags()
+
+	body := []byte(`[{"template":"golang-http","platform":"x86_64"}]`)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	if _, err := getTemplateInfo(server.URL); err != nil {
+		t.Fatalf("unexpected error populating the cache: %s", err)
+	}
+
+	offlineCache = true
+	templateStoreChecksum = "0000000000000000000000000000000000000000000000000000000000000000"
+
+	if _, err := getTemplateInfo(server.URL); err == nil {
+		t.Fatal("expected --offline to still re-verify the cached copy against --checksum")
+	}
+}